@@ -0,0 +1,44 @@
+package coinparam
+
+import (
+	"math/big"
+
+	"github.com/mit-dci/lit/wire"
+)
+
+// CalcDiffAdjustBTC implements the standard Bitcoin retarget: every
+// TargetTimespan/TargetTimePerBlock blocks, scale the previous target by the
+// actual timespan clamped to [timespan/RetargetAdjustmentFactor,
+// timespan*RetargetAdjustmentFactor].
+func CalcDiffAdjustBTC(
+	headers []*wire.BlockHeader, height int32, p *Params) (uint32, error) {
+	last := headers[len(headers)-1]
+
+	blocksPerRetarget := int32(p.TargetTimespan / p.TargetTimePerBlock)
+	if blocksPerRetarget == 0 || height%blocksPerRetarget != 0 {
+		return last.Bits, nil
+	}
+	if int32(len(headers)) <= blocksPerRetarget {
+		return p.PowLimitBits, nil
+	}
+
+	first := headers[len(headers)-int(blocksPerRetarget)]
+	targetTimespan := int64(p.TargetTimespan.Seconds())
+	actualTimespan := last.Timestamp.Unix() - first.Timestamp.Unix()
+	minTimespan := targetTimespan / p.RetargetAdjustmentFactor
+	maxTimespan := targetTimespan * p.RetargetAdjustmentFactor
+	if actualTimespan < minTimespan {
+		actualTimespan = minTimespan
+	}
+	if actualTimespan > maxTimespan {
+		actualTimespan = maxTimespan
+	}
+
+	newTarget := new(big.Int).Mul(CompactToBig(last.Bits), big.NewInt(actualTimespan))
+	newTarget.Div(newTarget, big.NewInt(targetTimespan))
+	if newTarget.Cmp(p.PowLimit) == 1 {
+		newTarget = p.PowLimit
+	}
+
+	return BigToCompact(newTarget), nil
+}
@@ -0,0 +1,221 @@
+package coinparam
+
+import (
+	"math/big"
+	"time"
+)
+
+var bigOneSub236 = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 236), bigOne)
+var bigOneSub224 = new(big.Int).Sub(new(big.Int).Lsh(bigOne, 224), bigOne)
+
+// VertcoinParams are the parameters for the main Vertcoin network. Vertcoin
+// used Kimoto Gravity Well for its difficulty retarget historically, before
+// moving on to other algorithms to address KGW's miner-hopping sensitivity.
+var VertcoinParams = Params{
+	Name:           "vtc",
+	NetMagicBytes:  0xdab5bffa,
+	DefaultPort:    "5889",
+	DefaultRPCPort: "5888",
+	DNSSeeds: []string{
+		"fr1.vtconline.org",
+		"uk1.vtconline.org",
+		"useast1.vtconline.org",
+		"vtc.alwayshashing.com",
+		"crypto.office-on-the.net",
+		"p2pool.kosmoplovci.org",
+	},
+
+	// Chain parameters
+	StartHeader: [80]byte{
+		0x02, 0x00, 0x00, 0x00, 0x36, 0xdc, 0x16, 0xc7, 0x71, 0x63,
+		0x1c, 0x52, 0xa4, 0x3d, 0xb7, 0xb0, 0xa9, 0x86, 0x95, 0x95,
+		0xed, 0x7d, 0xc1, 0x68, 0xe7, 0x2e, 0xaf, 0x0f, 0x55, 0x08,
+		0x02, 0x98, 0x9f, 0x5c, 0x7b, 0xe4, 0x37, 0xa6, 0x90, 0x76,
+		0x66, 0xa7, 0xba, 0x55, 0x75, 0xd8, 0x8a, 0xc5, 0x14, 0x01,
+		0x86, 0x11, 0x8e, 0x34, 0xe2, 0x4a, 0x04, 0x7b, 0x9d, 0x6e,
+		0x96, 0x41, 0xbb, 0x29, 0xe2, 0x04, 0xcb, 0x49, 0x3c, 0x53,
+		0x08, 0x58, 0x3f, 0xf4, 0x4d, 0x1b, 0x42, 0x22, 0x6e, 0x8a,
+	},
+	StartHeight:              598752,
+	AssumeDiffBefore:         602784,
+	MinHeaders:               4032,
+	DiffCalcFunction:         CalcDiffAdjustKGW,
+	FeePerByte:               100,
+	PowLimit:                 bigOneSub236,
+	PowLimitBits:             0x1e0fffff,
+	CoinbaseMaturity:         120,
+	SubsidyReductionInterval: 840000,
+	TargetTimespan:           time.Second * 302400, // 3.5 days
+	TargetTimePerBlock:       time.Second * 150,    // 150 seconds
+	RetargetAdjustmentFactor: 4,                    // 25% less, 400% more
+	ReduceMinDifficulty:      false,
+	MinDiffReductionTime:     time.Second * 150 * 2, // ?? unknown
+	GenerateSupported:        false,
+
+	BlockEnforceNumRequired: 1512,
+	BlockRejectNumRequired:  1915,
+	BlockUpgradeNumToCheck:  2016,
+
+	// Mempool parameters
+	RelayNonStdTxs: true,
+
+	// Address encoding magics
+	PubKeyHashAddrID: 0x47, // starts with V
+	ScriptHashAddrID: 0x05, // starts with 3
+	Bech32Prefix:     "vtc",
+	PrivateKeyID:     0x80,
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID: [4]byte{0x04, 0x35, 0x83, 0x94}, // starts with tprv
+	HDPublicKeyID:  [4]byte{0x04, 0x35, 0x87, 0xcf}, // starts with tpub
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType: 28,
+}
+
+// MonacoinParams are the parameters for the main Monacoin network. Like
+// Vertcoin, Monacoin used KGW historically.
+var MonacoinParams = Params{
+	Name:                     "mona",
+	NetMagicBytes:            0xfbc0b6db,
+	DefaultPort:              "9401",
+	DefaultRPCPort:           "9402",
+	MinHeaders:               4032,
+	DiffCalcFunction:         CalcDiffAdjustKGW,
+	FeePerByte:               100,
+	PowLimit:                 bigOneSub236,
+	PowLimitBits:             0x1e0fffff,
+	CoinbaseMaturity:         100,
+	SubsidyReductionInterval: 1051200,
+	TargetTimespan:           time.Second * 151200, // 1.75 days
+	TargetTimePerBlock:       time.Second * 90,
+	RetargetAdjustmentFactor: 4,
+	RelayNonStdTxs:           true,
+	PubKeyHashAddrID:         0x32, // starts with M
+	ScriptHashAddrID:         0x37, // starts with P
+	Bech32Prefix:             "mona",
+	PrivateKeyID:             0xB0,
+	HDPrivateKeyID:           [4]byte{0x04, 0x88, 0xad, 0xe4}, // xprv
+	HDPublicKeyID:            [4]byte{0x04, 0x88, 0xb2, 0x1e}, // xpub
+	HDCoinType:               22,
+}
+
+// DigibyteParams are the parameters for the main Digibyte network. DGB moved
+// to DigiShield to correct the same hopping vulnerability KGW had.
+var DigibyteParams = Params{
+	Name:                     "dgb",
+	NetMagicBytes:            0xfac3b6da,
+	DefaultPort:              "12024",
+	DefaultRPCPort:           "14022",
+	MinHeaders:               DigishieldBlockWindow,
+	DiffCalcFunction:         CalcDiffAdjustDigiShield,
+	FeePerByte:               10,
+	PowLimit:                 bigOneSub236,
+	PowLimitBits:             0x1e0fffff,
+	CoinbaseMaturity:         100,
+	SubsidyReductionInterval: 2102400,
+	TargetTimespan:           time.Second * 15,
+	TargetTimePerBlock:       time.Second * 15,
+	RetargetAdjustmentFactor: 4,
+	RelayNonStdTxs:           true,
+	PubKeyHashAddrID:         0x1e, // starts with D
+	ScriptHashAddrID:         0x3f,
+	Bech32Prefix:             "dgb",
+	PrivateKeyID:             0x80,
+	HDPrivateKeyID:           [4]byte{0x04, 0x88, 0xad, 0xe4},
+	HDPublicKeyID:            [4]byte{0x04, 0x88, 0xb2, 0x1e},
+	HDCoinType:               20,
+}
+
+// LitecoinParams are the parameters for the main Litecoin network. LTC uses
+// the standard Bitcoin retarget, scaled to its 2.5 minute block target.
+var LitecoinParams = Params{
+	Name:                     "ltc",
+	NetMagicBytes:            0xdbb6c0fb,
+	DefaultPort:              "9333",
+	DefaultRPCPort:           "9332",
+	MinHeaders:               2016,
+	DiffCalcFunction:         CalcDiffAdjustBTC,
+	FeePerByte:               100,
+	PowLimit:                 bigOneSub236,
+	PowLimitBits:             0x1e0fffff,
+	CoinbaseMaturity:         100,
+	SubsidyReductionInterval: 840000,
+	TargetTimespan:           time.Second * 302400, // 3.5 days
+	TargetTimePerBlock:       time.Second * 150,
+	RetargetAdjustmentFactor: 4,
+	RelayNonStdTxs:           true,
+	PubKeyHashAddrID:         0x30, // starts with L
+	ScriptHashAddrID:         0x32, // starts with M
+	Bech32Prefix:             "ltc",
+	PrivateKeyID:             0xB0,
+	HDPrivateKeyID:           [4]byte{0x04, 0x88, 0xad, 0xe4},
+	HDPublicKeyID:            [4]byte{0x04, 0x88, 0xb2, 0x1e},
+	HDCoinType:               2,
+}
+
+// BitcoinParams are the parameters for the main Bitcoin network.
+var BitcoinParams = Params{
+	Name:                     "btc",
+	NetMagicBytes:            0xd9b4bef9,
+	DefaultPort:              "8333",
+	DefaultRPCPort:           "8332",
+	MinHeaders:               2016,
+	DiffCalcFunction:         CalcDiffAdjustBTC,
+	FeePerByte:               1,
+	PowLimit:                 bigOneSub224,
+	PowLimitBits:             0x1d00ffff,
+	CoinbaseMaturity:         100,
+	SubsidyReductionInterval: 210000,
+	TargetTimespan:           time.Second * 1209600, // 14 days
+	TargetTimePerBlock:       time.Second * 600,
+	RetargetAdjustmentFactor: 4,
+	RelayNonStdTxs:           false,
+	PubKeyHashAddrID:         0x00,
+	ScriptHashAddrID:         0x05,
+	Bech32Prefix:             "bc",
+	PrivateKeyID:             0x80,
+	HDPrivateKeyID:           [4]byte{0x04, 0x88, 0xad, 0xe4}, // xprv
+	HDPublicKeyID:            [4]byte{0x04, 0x88, 0xb2, 0x1e}, // xpub
+	HDCoinType:               0,
+}
+
+// BitcoinTestNetParams are the parameters for the Bitcoin test network
+// (testnet3).
+var BitcoinTestNetParams = Params{
+	Name:                     "btc-testnet",
+	NetMagicBytes:            0x0709110b,
+	DefaultPort:              "18333",
+	DefaultRPCPort:           "18332",
+	MinHeaders:               2016,
+	DiffCalcFunction:         CalcDiffAdjustBTC,
+	FeePerByte:               1,
+	PowLimit:                 bigOneSub224,
+	PowLimitBits:             0x1d00ffff,
+	CoinbaseMaturity:         100,
+	SubsidyReductionInterval: 210000,
+	TargetTimespan:           time.Second * 1209600, // 14 days
+	TargetTimePerBlock:       time.Second * 600,
+	RetargetAdjustmentFactor: 4,
+	ReduceMinDifficulty:      true,
+	MinDiffReductionTime:     time.Minute * 20,
+	RelayNonStdTxs:           true,
+	PubKeyHashAddrID:         0x6f,
+	ScriptHashAddrID:         0xc4,
+	Bech32Prefix:             "tb",
+	PrivateKeyID:             0xef,
+	HDPrivateKeyID:           [4]byte{0x04, 0x35, 0x83, 0x94}, // tprv
+	HDPublicKeyID:            [4]byte{0x04, 0x35, 0x87, 0xcf}, // tpub
+	HDCoinType:               1,
+	TestCoin:                 true,
+}
+
+func init() {
+	Register(&VertcoinParams)
+	Register(&MonacoinParams)
+	Register(&DigibyteParams)
+	Register(&LitecoinParams)
+	Register(&BitcoinParams)
+	Register(&BitcoinTestNetParams)
+}
@@ -0,0 +1,55 @@
+package coinparam
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/mit-dci/lit/wire"
+)
+
+// DGW3BlockWindow is the number of blocks DGW v3 averages over, as used by
+// Dash.
+const DGW3BlockWindow = 24
+
+// CalcDiffAdjustDGW3 implements Dash's DGW v3 retarget: a running average
+// target over the last DGW3BlockWindow blocks, scaled by the clamped actual
+// timespan over that window.
+func CalcDiffAdjustDGW3(
+	headers []*wire.BlockHeader, height int32, p *Params) (uint32, error) {
+	if height-1 < DGW3BlockWindow {
+		return p.PowLimitBits, nil
+	}
+
+	idx := len(headers) - 1
+	avg := new(big.Int)
+	var i int64
+	tip := headers[idx].Timestamp
+	var earliest time.Time
+	for i = 0; i < DGW3BlockWindow; i++ {
+		h := headers[idx-int(i)]
+		target := CompactToBig(h.Bits)
+		avg.Mul(avg, big.NewInt(i))
+		avg.Add(avg, target)
+		avg.Div(avg, big.NewInt(i+1))
+		earliest = h.Timestamp
+	}
+
+	targetSpacing := int64(p.TargetTimePerBlock.Seconds())
+	actualTimespan := tip.Unix() - earliest.Unix()
+	minTimespan := targetSpacing * DGW3BlockWindow / 3
+	maxTimespan := targetSpacing * DGW3BlockWindow * 3
+	if actualTimespan < minTimespan {
+		actualTimespan = minTimespan
+	}
+	if actualTimespan > maxTimespan {
+		actualTimespan = maxTimespan
+	}
+
+	newTarget := new(big.Int).Mul(avg, big.NewInt(actualTimespan))
+	newTarget.Div(newTarget, big.NewInt(targetSpacing*DGW3BlockWindow))
+	if newTarget.Cmp(p.PowLimit) == 1 {
+		newTarget = p.PowLimit
+	}
+
+	return BigToCompact(newTarget), nil
+}
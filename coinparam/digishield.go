@@ -0,0 +1,54 @@
+package coinparam
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/mit-dci/lit/wire"
+)
+
+// DigishieldBlockWindow is the number of blocks DigiShield v3 averages over.
+const DigishieldBlockWindow = 6
+
+// CalcDiffAdjustDigiShield implements DigiByte's DigiShield v3 retarget: the
+// same running average as DGW, but with an asymmetric -16%/+32% clamp on the
+// actual timespan.
+func CalcDiffAdjustDigiShield(
+	headers []*wire.BlockHeader, height int32, p *Params) (uint32, error) {
+	if height-1 < DigishieldBlockWindow {
+		return p.PowLimitBits, nil
+	}
+
+	idx := len(headers) - 1
+	avg := new(big.Int)
+	var i int64
+	tip := headers[idx].Timestamp
+	var earliest time.Time
+	for i = 0; i < DigishieldBlockWindow; i++ {
+		h := headers[idx-int(i)]
+		target := CompactToBig(h.Bits)
+		avg.Mul(avg, big.NewInt(i))
+		avg.Add(avg, target)
+		avg.Div(avg, big.NewInt(i+1))
+		earliest = h.Timestamp
+	}
+
+	targetSpacing := int64(p.TargetTimePerBlock.Seconds())
+	actualTimespan := tip.Unix() - earliest.Unix()
+	minTimespan := targetSpacing * DigishieldBlockWindow * 84 / 100  // -16%
+	maxTimespan := targetSpacing * DigishieldBlockWindow * 132 / 100 // +32%
+	if actualTimespan < minTimespan {
+		actualTimespan = minTimespan
+	}
+	if actualTimespan > maxTimespan {
+		actualTimespan = maxTimespan
+	}
+
+	newTarget := new(big.Int).Mul(avg, big.NewInt(actualTimespan))
+	newTarget.Div(newTarget, big.NewInt(targetSpacing*DigishieldBlockWindow))
+	if newTarget.Cmp(p.PowLimit) == 1 {
+		newTarget = p.PowLimit
+	}
+
+	return BigToCompact(newTarget), nil
+}
@@ -0,0 +1,99 @@
+package coinparam
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/mit-dci/lit/wire"
+)
+
+// CalcDiffAdjustKGW uses Kimoto Gravity Well for difficulty adjustment. Used
+// historically in VTC, MONA etc.
+func CalcDiffAdjustKGW(
+	headers []*wire.BlockHeader, height int32, p *Params) (uint32, error) {
+	var minBlocks, maxBlocks int32
+	minBlocks = 144
+	maxBlocks = 4032
+
+	if height-1 < minBlocks {
+		return p.PowLimitBits, nil
+	}
+
+	idx := -2
+	currentBlock := headers[len(headers)+idx]
+	lastSolved := currentBlock
+
+	var blocksScanned, actualRate, targetRate int64
+	var difficultyAverage, previousDifficultyAverage big.Int
+	var rateAdjustmentRatio, eventHorizonDeviation float64
+	var eventHorizonDeviationFast, eventHorizonDevationSlow float64
+
+	currentHeight := height - 1
+
+	var i int32
+
+	for i = 1; currentHeight > 0; i++ {
+		if i > maxBlocks {
+			break
+		}
+
+		blocksScanned++
+
+		if i == 1 {
+			difficultyAverage = *CompactToBig(currentBlock.Bits)
+		} else {
+			compact := CompactToBig(currentBlock.Bits)
+
+			difference := new(big.Int).Sub(compact, &previousDifficultyAverage)
+			difference.Div(difference, big.NewInt(int64(i)))
+			difference.Add(difference, &previousDifficultyAverage)
+			difficultyAverage = *difference
+		}
+
+		previousDifficultyAverage = difficultyAverage
+
+		actualRate = lastSolved.Timestamp.Unix() - currentBlock.Timestamp.Unix()
+		targetRate = int64(p.TargetTimePerBlock.Seconds()) * blocksScanned
+		rateAdjustmentRatio = 1
+
+		if actualRate < 0 {
+			actualRate = 0
+		}
+
+		if actualRate != 0 && targetRate != 0 {
+			rateAdjustmentRatio = float64(targetRate) / float64(actualRate)
+		}
+
+		eventHorizonDeviation = 1 + (0.7084 *
+			math.Pow(float64(blocksScanned)/float64(minBlocks), -1.228))
+		eventHorizonDeviationFast = eventHorizonDeviation
+		eventHorizonDevationSlow = 1 / eventHorizonDeviation
+
+		if blocksScanned >= int64(minBlocks) &&
+			(rateAdjustmentRatio <= eventHorizonDevationSlow ||
+				rateAdjustmentRatio >= eventHorizonDeviationFast) {
+			break
+		}
+
+		if currentHeight <= 1 {
+			break
+		}
+
+		currentHeight--
+		idx--
+		currentBlock = headers[len(headers)+idx]
+	}
+
+	newTarget := difficultyAverage
+	if actualRate != 0 && targetRate != 0 {
+		newTarget.Mul(&newTarget, big.NewInt(actualRate))
+
+		newTarget.Div(&newTarget, big.NewInt(targetRate))
+	}
+
+	if newTarget.Cmp(p.PowLimit) == 1 {
+		newTarget = *p.PowLimit
+	}
+
+	return BigToCompact(&newTarget), nil
+}
@@ -0,0 +1,65 @@
+package coinparam
+
+import (
+	"math/big"
+
+	"github.com/mit-dci/lit/wire"
+)
+
+// LWMABlockWindow is the number of blocks LWMA-1 averages over.
+const LWMABlockWindow = 60
+
+// lwmaFutureTimeLimitMultiple bounds how far a solve time may run negative
+// before being clamped, guarding against timestamp manipulation the same way
+// KGW's event horizon does. Params has no dedicated FTL field, so this is
+// derived from the target spacing as Zawy's reference implementations do.
+const lwmaFutureTimeLimitMultiple = 2
+
+// CalcDiffAdjustLWMA implements Zawy's LWMA-1: a linearly-weighted moving
+// average of solve times, scaled against the plain (unweighted) average
+// target over the same window.
+func CalcDiffAdjustLWMA(
+	headers []*wire.BlockHeader, height int32, p *Params) (uint32, error) {
+	if height-1 < LWMABlockWindow+1 {
+		return p.PowLimitBits, nil
+	}
+
+	targetSpacing := int64(p.TargetTimePerBlock.Seconds())
+	futureTimeLimit := targetSpacing * lwmaFutureTimeLimitMultiple
+	maxSolveTime := targetSpacing * 6
+
+	idx := len(headers) - 1
+	weightedSum := new(big.Int)
+	sumTarget := new(big.Int)
+	n := int64(LWMABlockWindow)
+	var k int64
+	for k = 1; k <= n; k++ {
+		cur := headers[idx-int(n-k)]
+		prev := headers[idx-int(n-k)-1]
+
+		st := cur.Timestamp.Unix() - prev.Timestamp.Unix()
+		if st < -futureTimeLimit {
+			st = -futureTimeLimit
+		}
+		if st > maxSolveTime {
+			st = maxSolveTime
+		}
+
+		weightedSum.Add(weightedSum, big.NewInt(st*k))
+		sumTarget.Add(sumTarget, new(big.Int).Div(CompactToBig(cur.Bits), big.NewInt(n)))
+	}
+
+	denominator := n * (n + 1) / 2
+	lwma := weightedSum.Div(weightedSum, big.NewInt(denominator))
+	if lwma.Sign() < 1 {
+		lwma = big.NewInt(1)
+	}
+
+	newTarget := new(big.Int).Mul(sumTarget, lwma)
+	newTarget.Div(newTarget, big.NewInt(targetSpacing))
+	if newTarget.Cmp(p.PowLimit) == 1 {
+		newTarget = p.PowLimit
+	}
+
+	return BigToCompact(newTarget), nil
+}
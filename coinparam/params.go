@@ -0,0 +1,284 @@
+// Package coinparam holds the per-coin network parameters and difficulty
+// retarget functions used by the simulator, modelled on the coinparam
+// package used by viacoin/lit. Separating this out lets the simulator run
+// against any registered coin instead of being hard-coded to Vertcoin.
+package coinparam
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/mit-dci/lit/btcutil/chaincfg/chainhash"
+	"github.com/mit-dci/lit/wire"
+)
+
+var (
+	// bigOne is 1 represented as a big.Int.  It is defined here to avoid
+	// the overhead of creating it multiple times.
+	bigOne = big.NewInt(1)
+
+	// oneLsh256 is 1 shifted left 256 bits.  It is defined here to avoid
+	// the overhead of creating it multiple times.
+	oneLsh256 = new(big.Int).Lsh(bigOne, 256)
+)
+
+// Params defines a Bitcoin-derived network by its parameters.  These
+// parameters may be used by Bitcoin applications to differentiate networks
+// as well as addresses and keys for one network from those intended for use
+// on another network.
+type Params struct {
+	// Name defines a human-readable identifier for the network.
+	Name string
+
+	// NetMagicBytes defines the magic bytes used to identify the network.
+	NetMagicBytes uint32
+
+	// DefaultPort defines the default peer-to-peer port for the network.
+	DefaultPort string
+
+	// DefaultRPCPort defines the default RPC port for the reference
+	// wallet/node of the network, used to reach it via rpcclient.
+	DefaultRPCPort string
+
+	// DNSSeeds defines a list of DNS seeds for the network that are used
+	// as one method to discover peers.
+	DNSSeeds []string
+
+	// GenesisBlock defines the first block of the chain.
+	GenesisBlock *wire.MsgBlock
+
+	// GenesisHash is the starting block hash.
+	GenesisHash *chainhash.Hash
+
+	// The function used to calculate the proof of work value for a block
+	PoWFunction func(b []byte, height int32) chainhash.Hash
+
+	// ChainhashFunc is the hash function the chain uses to hash a block
+	// header (e.g. SHA256d for Bitcoin-derived chains, Scrypt for VTC/LTC's
+	// legacy algorithm, Lyra2REv2 for Monacoin, or Verthash for current
+	// Vertcoin). Solver.Solve falls back to it when PoWFunction is nil, so
+	// Scrypt/Lyra2REv2/Verthash-based chains can be wired in by setting
+	// this field alone, without a height-aware PoWFunction.
+	ChainhashFunc func(header []byte) chainhash.Hash
+
+	// The function used to calculate the difficulty of a given block
+	DiffCalcFunction func(
+		headers []*wire.BlockHeader, height int32, p *Params) (uint32, error)
+
+	//DiffCalcFunction func(r io.ReadSeeker, height, startheight int32, p *Params) (uint32, error)
+
+	// The block header to start downloading blocks from
+	StartHeader [80]byte
+
+	// The height of the StartHeader
+	StartHeight int32
+
+	// Assume the difficulty bits are valid before this header height
+	// This is needed for coins with variable retarget lookbacks that use
+	// StartHeader to offset the beginning of the header chain for SPV
+	AssumeDiffBefore int32
+
+	// The minimum number of headers to pass to the difficulty function.
+	// This is primarily intended for coins that have difficulty functions
+	// without fixed epoch lengths
+	MinHeaders int32
+
+	// Fee per byte for transactions
+	FeePerByte int64
+
+	// PowLimit defines the highest allowed proof of work value for a block
+	// as a uint256.
+	PowLimit *big.Int
+
+	// PowLimitBits defines the highest allowed proof of work value for a
+	// block in compact form.
+	PowLimitBits uint32
+
+	// CoinbaseMaturity is the number of blocks required before newly mined
+	// coins (coinbase transactions) can be spent.
+	CoinbaseMaturity uint16
+
+	// SubsidyReductionInterval is the interval of blocks before the subsidy
+	// is reduced.
+	SubsidyReductionInterval int32
+
+	// TargetTimespan is the desired amount of time that should elapse
+	// before the block difficulty requirement is examined to determine how
+	// it should be changed in order to maintain the desired block
+	// generation rate.
+	TargetTimespan time.Duration
+
+	// TargetTimePerBlock is the desired amount of time to generate each
+	// block.
+	TargetTimePerBlock time.Duration
+
+	// RetargetAdjustmentFactor is the adjustment factor used to limit
+	// the minimum and maximum amount of adjustment that can occur between
+	// difficulty retargets.
+	RetargetAdjustmentFactor int64
+
+	// ReduceMinDifficulty defines whether the network should reduce the
+	// minimum required difficulty after a long enough period of time has
+	// passed without finding a block.  This is really only useful for test
+	// networks and should not be set on a main network.
+	ReduceMinDifficulty bool
+
+	// MinDiffReductionTime is the amount of time after which the minimum
+	// required difficulty should be reduced when a block hasn't been found.
+	//
+	// NOTE: This only applies if ReduceMinDifficulty is true.
+	MinDiffReductionTime time.Duration
+
+	// GenerateSupported specifies whether or not CPU mining is allowed.
+	GenerateSupported bool
+
+	// Enforce current block version once network has
+	// upgraded.  This is part of BIP0034.
+	BlockEnforceNumRequired uint64
+
+	// Reject previous block versions once network has
+	// upgraded.  This is part of BIP0034.
+	BlockRejectNumRequired uint64
+
+	// The number of nodes to check.  This is part of BIP0034.
+	BlockUpgradeNumToCheck uint64
+
+	// Mempool parameters
+	RelayNonStdTxs bool
+
+	// Address encoding magics
+	PubKeyHashAddrID byte   // First byte of a P2PKH address
+	ScriptHashAddrID byte   // First byte of a P2SH address
+	PrivateKeyID     byte   // First byte of a WIF private key
+	Bech32Prefix     string // HRP for bech32 address
+
+	// BIP32 hierarchical deterministic extended key magics
+	HDPrivateKeyID [4]byte
+	HDPublicKeyID  [4]byte
+
+	// BIP44 coin type used in the hierarchical deterministic path for
+	// address generation.
+	HDCoinType uint32
+
+	// TestCoin, when true, indicates that the network deals with money that
+	// isn't worth anything.  This can be useful to skip over security code,
+	//
+	TestCoin bool
+}
+
+// CompactToBig converts a compact representation of a whole number N, held
+// in an unsigned 32-bit number, to a big integer.  The representation is
+// similar to IEEE754 floating point numbers.
+//
+// Like IEEE754 floating point, there are three basic components: the sign,
+// the exponent, and the mantissa.  They are broken out as follows:
+//
+//   - the most significant 8 bits represent the unsigned base 256 exponent
+//
+//   - bit 23 (the 24th bit) represents the sign bit
+//
+//   - the least significant 23 bits represent the mantissa
+//
+//     -------------------------------------------------
+//     |   Exponent     |    Sign    |    Mantissa     |
+//     -------------------------------------------------
+//     | 8 bits [31-24] | 1 bit [23] | 23 bits [22-00] |
+//     -------------------------------------------------
+//
+// The formula to calculate N is:
+//
+//	N = (-1^sign) * mantissa * 256^(exponent-3)
+//
+// This compact form is only used in bitcoin to encode unsigned 256-bit numbers
+// which represent difficulty targets, thus there really is not a need for a
+// sign bit, but it is implemented here to stay consistent with bitcoind.
+func CompactToBig(compact uint32) *big.Int {
+	// Extract the mantissa, sign bit, and exponent.
+	mantissa := compact & 0x007fffff
+	isNegative := compact&0x00800000 != 0
+	exponent := uint(compact >> 24)
+
+	// Since the base for the exponent is 256, the exponent can be treated
+	// as the number of bytes to represent the full 256-bit number.  So,
+	// treat the exponent as the number of bytes and shift the mantissa
+	// right or left accordingly.  This is equivalent to:
+	// N = mantissa * 256^(exponent-3)
+	var bn *big.Int
+	if exponent <= 3 {
+		mantissa >>= 8 * (3 - exponent)
+		bn = big.NewInt(int64(mantissa))
+	} else {
+		bn = big.NewInt(int64(mantissa))
+		bn.Lsh(bn, 8*(exponent-3))
+	}
+
+	// Make it negative if the sign bit is set.
+	if isNegative {
+		bn = bn.Neg(bn)
+	}
+
+	return bn
+}
+
+// BigToCompact converts a whole number N to a compact representation using
+// an unsigned 32-bit number.  The compact representation only provides 23
+// bits of precision, so values larger than (2^23 - 1) only encode the most
+// significant digits of the number.  See CompactToBig for details.
+func BigToCompact(n *big.Int) uint32 {
+	// No need to do any work if it's zero.
+	if n.Sign() == 0 {
+		return 0
+	}
+
+	// Since the base for the exponent is 256, the exponent can be treated
+	// as the number of bytes.  So, shift the number right or left
+	// accordingly.  This is equivalent to:
+	// mantissa = mantissa / 256^(exponent-3)
+	var mantissa uint32
+	exponent := uint(len(n.Bytes()))
+	if exponent <= 3 {
+		mantissa = uint32(n.Bits()[0])
+		mantissa <<= 8 * (3 - exponent)
+	} else {
+		// Use a copy to avoid modifying the caller's original number.
+		tn := new(big.Int).Set(n)
+		mantissa = uint32(tn.Rsh(tn, 8*(exponent-3)).Bits()[0])
+	}
+
+	// When the mantissa already has the sign bit set, the number is too
+	// large to fit into the available 23-bits, so divide the number by 256
+	// and increment the exponent accordingly.
+	if mantissa&0x00800000 != 0 {
+		mantissa >>= 8
+		exponent++
+	}
+
+	// Pack the exponent, sign bit, and mantissa into an unsigned 32-bit
+	// int and return it.
+	compact := uint32(exponent<<24) | mantissa
+	if n.Sign() < 0 {
+		compact |= 0x00800000
+	}
+	return compact
+}
+
+// CalcWork calculates a work value from difficulty bits.  Bitcoin increases
+// the difficulty for generating a block by decreasing the value which the
+// generated hash must be less than.  This difficulty target is stored in
+// each block header using a compact representation as described in the
+// documentation for CompactToBig.  The main chain is selected by choosing
+// the chain that has the most proof of work (PoW) weight represented by the
+// work value.
+func CalcWork(bits uint32) *big.Int {
+	// Return a work value of zero if the passed difficulty bits represent
+	// a negative number. Note this should not happen in practice with valid
+	// blocks, but an invalid block could trigger it.
+	difficultyNum := CompactToBig(bits)
+	if difficultyNum.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+
+	// (1 << 256) / (difficultyNum + 1)
+	denominator := new(big.Int).Add(difficultyNum, bigOne)
+	return new(big.Int).Div(oneLsh256, denominator)
+}
@@ -0,0 +1,26 @@
+package coinparam
+
+import "fmt"
+
+// registered holds every Params registered with the package, keyed by Name.
+var registered = make(map[string]*Params)
+
+// Register adds a coin's Params to the registry so it can later be looked
+// up by name via ByName. It panics if a Params with the same Name has
+// already been registered, mirroring the behavior of the chaincfg package
+// this is modelled on.
+func Register(p *Params) {
+	if _, ok := registered[p.Name]; ok {
+		panic(fmt.Sprintf("coinparam: duplicate registration of coin %q", p.Name))
+	}
+	registered[p.Name] = p
+}
+
+// ByName looks up a registered coin's Params by name.
+func ByName(name string) (*Params, error) {
+	p, ok := registered[name]
+	if !ok {
+		return nil, fmt.Errorf("coinparam: unknown coin %q", name)
+	}
+	return p, nil
+}
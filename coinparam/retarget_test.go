@@ -0,0 +1,246 @@
+package coinparam
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mit-dci/lit/wire"
+)
+
+// makeHeaders returns n headers with Bits set uniformly to bits, spaced
+// spacingSeconds apart starting at a fixed, arbitrary epoch.
+func makeHeaders(n int, bits uint32, spacingSeconds int64) []*wire.BlockHeader {
+	start := time.Unix(1600000000, 0)
+	headers := make([]*wire.BlockHeader, n)
+	for i := 0; i < n; i++ {
+		headers[i] = &wire.BlockHeader{
+			Bits:      bits,
+			Timestamp: start.Add(time.Duration(int64(i)*spacingSeconds) * time.Second),
+		}
+	}
+	return headers
+}
+
+func testParams() *Params {
+	return &Params{
+		PowLimit:                 CompactToBig(0x1d00ffff),
+		PowLimitBits:             0x1d00ffff,
+		TargetTimespan:           time.Second * 1209600, // 14 days, like Bitcoin
+		TargetTimePerBlock:       time.Second * 600,
+		RetargetAdjustmentFactor: 4,
+	}
+}
+
+// TestCalcDiffAdjustBTCUsesCorrectRetargetWindow pins down the boundary of
+// the retarget window: Bitcoin's GetNextWorkRequired walks back
+// blocksPerRetarget-1 blocks from the tip, i.e. the "first" block is
+// headers[len(headers)-blocksPerRetarget], not one block further back. Only
+// the timestamps of that "first" block and the tip matter to the formula, so
+// this gives headers[0] a timestamp that would only be picked up by the old,
+// off-by-one index and checks it is ignored. The window starts below
+// PowLimit, so the doubled timespan the old index would see shows up as a
+// changed target instead of being clamped away.
+func TestCalcDiffAdjustBTCUsesCorrectRetargetWindow(t *testing.T) {
+	p := testParams()
+	blocksPerRetarget := int32(p.TargetTimespan / p.TargetTimePerBlock)
+	startBits := uint32(0x1c00ffff)
+
+	headers := makeHeaders(int(blocksPerRetarget)+1, startBits, 0)
+	epoch := time.Unix(1600000000, 0)
+	for _, h := range headers {
+		h.Timestamp = epoch
+	}
+	// One retarget period too early: only reached by the old, incorrect index.
+	headers[0].Timestamp = epoch.Add(-p.TargetTimespan)
+	// The correct "first" block for this window.
+	headers[1].Timestamp = epoch
+	// Tip, exactly one targetTimespan after the correct "first" block.
+	headers[len(headers)-1].Timestamp = epoch.Add(p.TargetTimespan)
+
+	bits, err := CalcDiffAdjustBTC(headers, blocksPerRetarget, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustBTC returned error: %v", err)
+	}
+	if bits != startBits {
+		t.Fatalf("expected unchanged bits %08x using the correct window start "+
+			"(headers[len(headers)-blocksPerRetarget]), got %08x -- this is "+
+			"the off-by-one regression where the window starts one block too "+
+			"early", startBits, bits)
+	}
+}
+
+// TestCalcDiffAdjustBTCNotARetargetHeight asserts the difficulty is left
+// alone between retarget heights.
+func TestCalcDiffAdjustBTCNotARetargetHeight(t *testing.T) {
+	p := testParams()
+	headers := makeHeaders(10, 0x1d00eeee, int64(p.TargetTimePerBlock.Seconds()))
+
+	bits, err := CalcDiffAdjustBTC(headers, 5, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustBTC returned error: %v", err)
+	}
+	if bits != headers[len(headers)-1].Bits {
+		t.Fatalf("expected last block's bits %08x unchanged at a non-retarget "+
+			"height, got %08x", headers[len(headers)-1].Bits, bits)
+	}
+}
+
+// TestCalcDiffAdjustBTCSlowBlocksEaseDifficulty asserts that a window mined
+// slower than target makes the next target easier (clamped to PowLimit here,
+// since the window is mined at 2x the target spacing).
+func TestCalcDiffAdjustBTCSlowBlocksEaseDifficulty(t *testing.T) {
+	p := testParams()
+	blocksPerRetarget := int32(p.TargetTimespan / p.TargetTimePerBlock)
+	targetSpacing := int64(p.TargetTimePerBlock.Seconds())
+
+	headers := makeHeaders(int(blocksPerRetarget)+1, p.PowLimitBits, targetSpacing*2)
+
+	bits, err := CalcDiffAdjustBTC(headers, blocksPerRetarget, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustBTC returned error: %v", err)
+	}
+	if CompactToBig(bits).Cmp(p.PowLimit) != 0 {
+		t.Fatalf("expected the retarget to clamp to PowLimit when blocks run "+
+			"2x slower than target, got bits %08x", bits)
+	}
+}
+
+func TestCalcDiffAdjustDGW3BelowWindowReturnsPowLimit(t *testing.T) {
+	p := testParams()
+	headers := makeHeaders(5, 0x1d00eeee, int64(p.TargetTimePerBlock.Seconds()))
+
+	bits, err := CalcDiffAdjustDGW3(headers, 5, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustDGW3 returned error: %v", err)
+	}
+	if bits != p.PowLimitBits {
+		t.Fatalf("expected PowLimitBits before DGW3BlockWindow blocks have "+
+			"elapsed, got %08x", bits)
+	}
+}
+
+// TestCalcDiffAdjustDGW3RespondsToBlockTime asserts the direction of the
+// adjustment: a window mined faster than target tightens the next target
+// (smaller number), a window mined slower loosens it (larger number).
+func TestCalcDiffAdjustDGW3RespondsToBlockTime(t *testing.T) {
+	p := testParams()
+	startBits := uint32(0x1d00aaaa)
+	targetSpacing := int64(p.TargetTimePerBlock.Seconds())
+	height := int32(DGW3BlockWindow + 1)
+
+	fast := makeHeaders(int(height), startBits, targetSpacing/2)
+	fastBits, err := CalcDiffAdjustDGW3(fast, height, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustDGW3 (fast) returned error: %v", err)
+	}
+
+	slow := makeHeaders(int(height), startBits, targetSpacing*2)
+	slowBits, err := CalcDiffAdjustDGW3(slow, height, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustDGW3 (slow) returned error: %v", err)
+	}
+
+	if CompactToBig(fastBits).Cmp(CompactToBig(startBits)) >= 0 {
+		t.Fatalf("expected a faster-than-target window to tighten the target "+
+			"below %08x, got %08x", startBits, fastBits)
+	}
+	if CompactToBig(slowBits).Cmp(CompactToBig(startBits)) <= 0 {
+		t.Fatalf("expected a slower-than-target window to loosen the target "+
+			"above %08x, got %08x", startBits, slowBits)
+	}
+}
+
+func TestCalcDiffAdjustDigiShieldBelowWindowReturnsPowLimit(t *testing.T) {
+	p := testParams()
+	headers := makeHeaders(3, 0x1d00eeee, int64(p.TargetTimePerBlock.Seconds()))
+
+	bits, err := CalcDiffAdjustDigiShield(headers, 3, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustDigiShield returned error: %v", err)
+	}
+	if bits != p.PowLimitBits {
+		t.Fatalf("expected PowLimitBits before DigishieldBlockWindow blocks "+
+			"have elapsed, got %08x", bits)
+	}
+}
+
+// TestCalcDiffAdjustDigiShieldRespondsToBlockTime mirrors
+// TestCalcDiffAdjustDGW3RespondsToBlockTime for DigiShield's asymmetric
+// -16%/+32% clamp.
+func TestCalcDiffAdjustDigiShieldRespondsToBlockTime(t *testing.T) {
+	p := testParams()
+	startBits := uint32(0x1d00aaaa)
+	targetSpacing := int64(p.TargetTimePerBlock.Seconds())
+	height := int32(DigishieldBlockWindow + 1)
+
+	fast := makeHeaders(int(height), startBits, targetSpacing/2)
+	fastBits, err := CalcDiffAdjustDigiShield(fast, height, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustDigiShield (fast) returned error: %v", err)
+	}
+
+	slow := makeHeaders(int(height), startBits, targetSpacing*2)
+	slowBits, err := CalcDiffAdjustDigiShield(slow, height, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustDigiShield (slow) returned error: %v", err)
+	}
+
+	if CompactToBig(fastBits).Cmp(CompactToBig(startBits)) >= 0 {
+		t.Fatalf("expected a faster-than-target window to tighten the target "+
+			"below %08x, got %08x", startBits, fastBits)
+	}
+	if CompactToBig(slowBits).Cmp(CompactToBig(startBits)) <= 0 {
+		t.Fatalf("expected a slower-than-target window to loosen the target "+
+			"above %08x, got %08x", startBits, slowBits)
+	}
+}
+
+func TestCalcDiffAdjustLWMAStableAtTarget(t *testing.T) {
+	p := testParams()
+	headers := makeHeaders(LWMABlockWindow+2, p.PowLimitBits, int64(p.TargetTimePerBlock.Seconds()))
+
+	bits, err := CalcDiffAdjustLWMA(headers, LWMABlockWindow+2, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustLWMA returned error: %v", err)
+	}
+	if bits != p.PowLimitBits {
+		t.Fatalf("expected unchanged bits %08x when every solve time matched "+
+			"the target spacing, got %08x", p.PowLimitBits, bits)
+	}
+}
+
+func TestCalcDiffAdjustLWMABelowWindowReturnsPowLimit(t *testing.T) {
+	p := testParams()
+	headers := makeHeaders(5, 0x1d00eeee, int64(p.TargetTimePerBlock.Seconds()))
+
+	bits, err := CalcDiffAdjustLWMA(headers, 5, p)
+	if err != nil {
+		t.Fatalf("CalcDiffAdjustLWMA returned error: %v", err)
+	}
+	if bits != p.PowLimitBits {
+		t.Fatalf("expected PowLimitBits before LWMABlockWindow+1 blocks have "+
+			"elapsed, got %08x", bits)
+	}
+}
+
+func TestCompactToBigBigToCompactRoundTrip(t *testing.T) {
+	// Each of these is already in canonical compact form (as produced by
+	// BigToCompact), so round-tripping through CompactToBig must reproduce
+	// it exactly.
+	cases := []uint32{
+		0x1d00ffff, // Bitcoin mainnet genesis bits
+		0x1b0404cb,
+		0x207fffff, // regtest PowLimitBits
+		0x1c3fffc0,
+		0x1e0ffff0,
+		0,
+	}
+
+	for _, bits := range cases {
+		n := CompactToBig(bits)
+		got := BigToCompact(n)
+		if got != bits {
+			t.Errorf("BigToCompact(CompactToBig(%08x)) = %08x, want %08x", bits, got, bits)
+		}
+	}
+}
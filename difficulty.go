@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/gertjaap/kgwsimulator/coinparam"
+	"github.com/mit-dci/lit/btcutil/chaincfg/chainhash"
+	"github.com/mit-dci/lit/wire"
+)
+
+// DifficultyAlgorithm is implemented by every difficulty retarget scheme the
+// simulator knows about. Calc returns the difficulty bits that should apply
+// to the block following the passed header chain. MinHeaders reports how
+// many headers the algorithm needs available before it can produce a
+// meaningful result; callers should fall back to the network's PowLimitBits
+// until that many headers have been seen.
+type DifficultyAlgorithm interface {
+	Calc(headers []*wire.BlockHeader, height int32, p *coinparam.Params) (uint32, error)
+	MinHeaders() int32
+}
+
+// algorithms holds every difficulty algorithm registered with the simulator,
+// keyed by the name passed to the --algo flag. Each one delegates its actual
+// math to the coinparam difficulty function library, so an --algo name runs
+// the same function a coin registers as its DiffCalcFunction. --algo is
+// chosen independently of --coin; the coin's DiffCalcFunction is not
+// consulted.
+var algorithms = map[string]DifficultyAlgorithm{
+	"kgw":        &kgwAlgorithm{},
+	"dgw3":       &dgw3Algorithm{},
+	"lwma":       &lwmaAlgorithm{},
+	"btc":        &btcAlgorithm{},
+	"digishield": &digishieldAlgorithm{},
+}
+
+// algorithmByName looks up a registered DifficultyAlgorithm by the name used
+// on the command line.
+func algorithmByName(name string) (DifficultyAlgorithm, error) {
+	algo, ok := algorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown difficulty algorithm %q", name)
+	}
+	return algo, nil
+}
+
+// kgwAlgorithm adapts coinparam.CalcDiffAdjustKGW to the DifficultyAlgorithm
+// interface. Used historically by VTC, MONA and peers.
+type kgwAlgorithm struct{}
+
+func (k *kgwAlgorithm) MinHeaders() int32 { return 144 }
+
+func (k *kgwAlgorithm) Calc(
+	headers []*wire.BlockHeader, height int32, p *coinparam.Params) (uint32, error) {
+	return coinparam.CalcDiffAdjustKGW(headers, height, p)
+}
+
+// dgw3Algorithm adapts coinparam.CalcDiffAdjustDGW3 to the
+// DifficultyAlgorithm interface. Dash's DGW v3 retarget.
+type dgw3Algorithm struct{}
+
+func (d *dgw3Algorithm) MinHeaders() int32 { return coinparam.DGW3BlockWindow }
+
+func (d *dgw3Algorithm) Calc(
+	headers []*wire.BlockHeader, height int32, p *coinparam.Params) (uint32, error) {
+	return coinparam.CalcDiffAdjustDGW3(headers, height, p)
+}
+
+// digishieldAlgorithm adapts coinparam.CalcDiffAdjustDigiShield to the
+// DifficultyAlgorithm interface. DigiByte's DigiShield v3 retarget.
+type digishieldAlgorithm struct{}
+
+func (d *digishieldAlgorithm) MinHeaders() int32 { return coinparam.DigishieldBlockWindow }
+
+func (d *digishieldAlgorithm) Calc(
+	headers []*wire.BlockHeader, height int32, p *coinparam.Params) (uint32, error) {
+	return coinparam.CalcDiffAdjustDigiShield(headers, height, p)
+}
+
+// lwmaAlgorithm adapts coinparam.CalcDiffAdjustLWMA to the
+// DifficultyAlgorithm interface. Zawy's LWMA-1.
+type lwmaAlgorithm struct{}
+
+func (l *lwmaAlgorithm) MinHeaders() int32 { return coinparam.LWMABlockWindow + 1 }
+
+func (l *lwmaAlgorithm) Calc(
+	headers []*wire.BlockHeader, height int32, p *coinparam.Params) (uint32, error) {
+	return coinparam.CalcDiffAdjustLWMA(headers, height, p)
+}
+
+// btcAlgorithm adapts coinparam.CalcDiffAdjustBTC to the DifficultyAlgorithm
+// interface. The standard Bitcoin retarget.
+type btcAlgorithm struct{}
+
+func (b *btcAlgorithm) MinHeaders() int32 { return 1 }
+
+func (b *btcAlgorithm) Calc(
+	headers []*wire.BlockHeader, height int32, p *coinparam.Params) (uint32, error) {
+	return coinparam.CalcDiffAdjustBTC(headers, height, p)
+}
+
+// runCompareAlgos simulates numBlocks blocks independently under every
+// registered difficulty algorithm, starting from the same header chain,
+// height and hashrate, and prints the resulting diff bits and solve times
+// for each algorithm side by side.
+func runCompareAlgos(
+	seedHeaders []*wire.BlockHeader, startHeight int64, p *coinparam.Params,
+	hashRate *big.Int, numBlocks int64, startDiff uint32) {
+
+	names := make([]string, 0, len(algorithms))
+	for name := range algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	header := fmt.Sprintf("|%20s|", "Block Height")
+	for _, name := range names {
+		header += fmt.Sprintf("%20s|%20s|", name+" bits", name+" time")
+	}
+	fmt.Println(header)
+
+	type algoState struct {
+		headers []*wire.BlockHeader
+		diff    uint32
+		best    *wire.BlockHeader
+	}
+
+	states := make(map[string]*algoState, len(names))
+	for _, name := range names {
+		headersCopy := make([]*wire.BlockHeader, len(seedHeaders))
+		copy(headersCopy, seedHeaders)
+		states[name] = &algoState{
+			headers: headersCopy,
+			diff:    startDiff,
+			best:    headersCopy[len(headersCopy)-1],
+		}
+	}
+
+	nullHash, _ := chainhash.NewHashFromStr("0000000000000000000000000000000000000000000000000000000000000000")
+	height := startHeight
+	for i := int64(0); i < numBlocks; i++ {
+		height++
+		row := fmt.Sprintf("|%20d|", height)
+		for _, name := range names {
+			st := states[name]
+
+			workForBlock := coinparam.CalcWork(st.diff)
+			timeInSeconds := new(big.Int).Div(workForBlock, hashRate).Int64()
+			seconds := timeInSeconds % 60
+			minutes := (timeInSeconds - seconds) / 60
+			row += fmt.Sprintf("%20x|%20s|", st.diff, fmt.Sprintf("%dm%02ds", minutes, seconds))
+
+			newHeader := wire.NewBlockHeader(nullHash, nullHash, st.diff, 0)
+			newHeader.Timestamp = st.best.Timestamp.Add(time.Second * time.Duration(timeInSeconds))
+			st.headers = append(st.headers[1:], newHeader)
+			st.best = st.headers[len(st.headers)-1]
+
+			newDiff, err := algorithms[name].Calc(st.headers, int32(height), p)
+			if err != nil {
+				log.Fatal(err)
+			}
+			st.diff = newDiff
+		}
+		fmt.Println(row)
+	}
+}
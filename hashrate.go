@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gertjaap/kgwsimulator/coinparam"
+	"github.com/mit-dci/lit/wire"
+	"gopkg.in/yaml.v2"
+)
+
+// HashrateProfile describes how the network's total hashrate evolves over
+// the course of a simulation. HashrateAt is consulted once per simulated
+// block, and is passed the difficulty bits the block will be mined under so
+// that profiles such as HoppingAttacker can react to the current difficulty.
+type HashrateProfile interface {
+	HashrateAt(height int32, diffBits uint32) *big.Int
+}
+
+// ConstantHashrate is a HashrateProfile that never changes.
+type ConstantHashrate struct {
+	RateHs *big.Int
+}
+
+func (c *ConstantHashrate) HashrateAt(height int32, diffBits uint32) *big.Int {
+	return c.RateHs
+}
+
+// HashrateStep is a single entry of a StepwiseHashrate profile: the
+// hashrate, in H/s, that takes effect starting at StartBlock.
+type HashrateStep struct {
+	StartBlock int32 `json:"startBlock" yaml:"startBlock"`
+	HashRate   int64 `json:"hashRate" yaml:"hashRate"`
+}
+
+// StepwiseHashrate is a HashrateProfile driven by a list of
+// {startBlock, hashRate} entries, typically loaded from a JSON or YAML file.
+type StepwiseHashrate struct {
+	Steps []HashrateStep
+}
+
+func (s *StepwiseHashrate) HashrateAt(height int32, diffBits uint32) *big.Int {
+	rate := int64(0)
+	for _, step := range s.Steps {
+		if step.StartBlock <= height {
+			rate = step.HashRate
+		}
+	}
+	return big.NewInt(rate)
+}
+
+// LoadStepwiseHashrate reads a list of HashrateStep entries from a JSON or
+// YAML file, trying JSON first and falling back to YAML.
+func LoadStepwiseHashrate(path string) (*StepwiseHashrate, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var steps []HashrateStep
+	if err := json.Unmarshal(data, &steps); err == nil {
+		return &StepwiseHashrate{Steps: steps}, nil
+	}
+	if err := yaml.Unmarshal(data, &steps); err == nil {
+		return &StepwiseHashrate{Steps: steps}, nil
+	}
+
+	return nil, fmt.Errorf("%s is not a valid JSON or YAML hashrate profile", path)
+}
+
+// OscillatingHashrate is a HashrateProfile that varies sinusoidally around a
+// base rate, useful for simulating seasonal or day/night hashrate swings.
+type OscillatingHashrate struct {
+	Base         int64
+	Amplitude    int64
+	PeriodBlocks int32
+}
+
+func (o *OscillatingHashrate) HashrateAt(height int32, diffBits uint32) *big.Int {
+	if o.PeriodBlocks <= 0 {
+		return big.NewInt(o.Base)
+	}
+
+	phase := float64(height%o.PeriodBlocks) / float64(o.PeriodBlocks)
+	rate := float64(o.Base) + float64(o.Amplitude)*math.Sin(2*math.Pi*phase)
+	if rate < 0 {
+		rate = 0
+	}
+
+	return big.NewInt(int64(rate))
+}
+
+// HoppingAttacker is a HashrateProfile that reproduces the miner-hopping
+// behavior KGW was designed to withstand: it adds AttackerRate on top of
+// BaseRate once the network difficulty has dropped to TriggerDiffBits or
+// easier, and withdraws it again once the difficulty has recovered to
+// ExitDiffBits or harder.
+type HoppingAttacker struct {
+	BaseRate        int64
+	AttackerRate    int64
+	TriggerDiffBits uint32
+	ExitDiffBits    uint32
+
+	active bool
+}
+
+// Active reports whether the attacker's hashrate is currently applied.
+func (h *HoppingAttacker) Active() bool {
+	return h.active
+}
+
+func (h *HoppingAttacker) HashrateAt(height int32, diffBits uint32) *big.Int {
+	target := coinparam.CompactToBig(diffBits)
+	triggerTarget := coinparam.CompactToBig(h.TriggerDiffBits)
+	exitTarget := coinparam.CompactToBig(h.ExitDiffBits)
+
+	if !h.active && target.Cmp(triggerTarget) >= 0 {
+		h.active = true
+	} else if h.active && target.Cmp(exitTarget) <= 0 {
+		h.active = false
+	}
+
+	rate := big.NewInt(h.BaseRate)
+	if h.active {
+		rate.Add(rate, big.NewInt(h.AttackerRate))
+	}
+
+	return rate
+}
+
+// buildHashrateProfile constructs the HashrateProfile selected by mode,
+// falling back to a ConstantHashrate at baseRate when mode is "constant".
+func buildHashrateProfile(
+	mode string, file string, baseRate *big.Int, amplitude int64, period int,
+	attackerRate int64, triggerBitsHex string, exitBitsHex string) (HashrateProfile, error) {
+
+	switch mode {
+	case "constant":
+		return &ConstantHashrate{RateHs: baseRate}, nil
+
+	case "stepwise":
+		if file == "" {
+			return nil, fmt.Errorf("--hashrate-mode stepwise requires --hashrate-file")
+		}
+		return LoadStepwiseHashrate(file)
+
+	case "oscillating":
+		return &OscillatingHashrate{
+			Base:         baseRate.Int64(),
+			Amplitude:    amplitude,
+			PeriodBlocks: int32(period),
+		}, nil
+
+	case "hopping":
+		triggerBits, err := strconv.ParseUint(triggerBitsHex, 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --attacker-trigger-bits: %v", err)
+		}
+		exitBits, err := strconv.ParseUint(exitBitsHex, 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --attacker-exit-bits: %v", err)
+		}
+		return &HoppingAttacker{
+			BaseRate:        baseRate.Int64(),
+			AttackerRate:    attackerRate,
+			TriggerDiffBits: uint32(triggerBits),
+			ExitDiffBits:    uint32(exitBits),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown hashrate mode %q", mode)
+}
+
+// sampleBlockInterval draws a block solve time in seconds from the
+// exponential inter-arrival distribution t = -ln(U) * work / hashRate,
+// U ~ Uniform(0, 1]. It returns an error if hashRate is zero or negative,
+// since that would otherwise divide through to +Inf and silently corrupt
+// the resulting interval.
+func sampleBlockInterval(rng *rand.Rand, work *big.Int, hashRate *big.Int) (int64, error) {
+	if hashRate.Sign() <= 0 {
+		return 0, fmt.Errorf("hashrate profile returned non-positive hashrate %s", hashRate.String())
+	}
+
+	workF := new(big.Float).SetInt(work)
+	hashRateF := new(big.Float).SetInt(hashRate)
+	meanF := new(big.Float).Quo(workF, hashRateF)
+	mean, _ := meanF.Float64()
+
+	u := rng.Float64()
+	for u <= 0 {
+		u = rng.Float64()
+	}
+
+	return int64(-math.Log(u) * mean), nil
+}
+
+// medianTimeOfLastHeaders returns the median timestamp of up to the last 11
+// headers, mirroring Bitcoin's median-time-past (MTP) rule.
+func medianTimeOfLastHeaders(headers []*wire.BlockHeader) time.Time {
+	n := 11
+	if len(headers) < n {
+		n = len(headers)
+	}
+
+	times := make([]int64, n)
+	for i := 0; i < n; i++ {
+		times[i] = headers[len(headers)-1-i].Timestamp.Unix()
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+	return time.Unix(times[len(times)/2], 0)
+}
+
+// jitterTimestamp perturbs t by a random offset in [-jitterSeconds,
+// jitterSeconds], then clamps the result to stay after the median-time-past
+// of headers so the simulated chain can exercise KGW's sensitivity to
+// timestamp manipulation without producing an outright invalid header.
+func jitterTimestamp(
+	rng *rand.Rand, t time.Time, headers []*wire.BlockHeader, jitterSeconds int) time.Time {
+	if jitterSeconds <= 0 {
+		return t
+	}
+
+	offset := rng.Intn(2*jitterSeconds+1) - jitterSeconds
+	jittered := t.Add(time.Duration(offset) * time.Second)
+
+	mtp := medianTimeOfLastHeaders(headers)
+	if !jittered.After(mtp) {
+		jittered = mtp.Add(time.Second)
+	}
+
+	return jittered
+}
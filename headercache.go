@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/mit-dci/lit/wire"
+)
+
+// numSeedHeaders is the number of headers the simulator walks back from the
+// chain tip before running a simulation or serving requests.
+const numSeedHeaders = 4200
+
+// cachedHeaderChain is the on-disk representation written by --header-cache,
+// holding numSeedHeaders wire-serialized headers (oldest first) and the
+// height of the last one. Coin records which coin the chain belongs to, so a
+// cache path reused across coins is detected rather than silently returning
+// the wrong chain.
+type cachedHeaderChain struct {
+	Coin    string   `json:"coin"`
+	Height  int64    `json:"height"`
+	Headers []string `json:"headers"`
+}
+
+// fetchHeaders returns the last numSeedHeaders headers of client's best
+// chain, along with the height of the chain tip. When cachePath is set and
+// already holds a cached chain for coinName, client is never contacted;
+// otherwise the freshly fetched chain is written there so later runs can
+// skip the RPC walk back over numSeedHeaders blocks.
+func fetchHeaders(client *rpcclient.Client, coinName string, cachePath string) ([]*wire.BlockHeader, int64, error) {
+	if cachePath != "" {
+		if headers, height, err := loadHeaderCache(cachePath, coinName); err == nil {
+			return headers, height, nil
+		}
+	}
+
+	headers, height, err := fetchHeadersFromRPC(client)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if cachePath != "" {
+		if err := saveHeaderCache(cachePath, coinName, headers, height); err != nil {
+			log.Printf("warning: failed to write header cache %s: %v", cachePath, err)
+		}
+	}
+
+	return headers, height, nil
+}
+
+// fetchHeadersFromRPC walks the chain backwards from its tip, gathering the
+// last numSeedHeaders headers via client.
+func fetchHeadersFromRPC(client *rpcclient.Client) ([]*wire.BlockHeader, int64, error) {
+	lastBlocks := make([]*wire.BlockHeader, 0, numSeedHeaders)
+
+	hash, err := client.GetBestBlockHash()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	height, err := client.GetBlockCount()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for len(lastBlocks) < numSeedHeaders {
+		header, err := client.GetBlockHeader(hash)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		bh := new(wire.BlockHeader)
+		var buf bytes.Buffer
+		header.Serialize(&buf)
+		buf2 := bytes.NewBuffer(buf.Bytes())
+		bh.Deserialize(buf2)
+		lastBlocks = append([]*wire.BlockHeader{bh}, lastBlocks...)
+
+		hash = &header.PrevBlock
+	}
+
+	return lastBlocks, height, nil
+}
+
+// loadHeaderCache reads a header chain previously written by saveHeaderCache,
+// rejecting it if it was cached for a different coin than coinName.
+func loadHeaderCache(path string, coinName string) ([]*wire.BlockHeader, int64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var cached cachedHeaderChain
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, 0, err
+	}
+
+	if cached.Coin != coinName {
+		return nil, 0, fmt.Errorf("header cache %s was fetched for coin %q, not %q", path, cached.Coin, coinName)
+	}
+
+	headers := make([]*wire.BlockHeader, len(cached.Headers))
+	for i, encoded := range cached.Headers {
+		raw, err := hex.DecodeString(encoded)
+		if err != nil {
+			return nil, 0, fmt.Errorf("header cache %s is corrupt: %v", path, err)
+		}
+
+		h := new(wire.BlockHeader)
+		if err := h.Deserialize(bytes.NewReader(raw)); err != nil {
+			return nil, 0, fmt.Errorf("header cache %s is corrupt: %v", path, err)
+		}
+		headers[i] = h
+	}
+
+	return headers, cached.Height, nil
+}
+
+// saveHeaderCache writes headers and height to path so a later run with the
+// same --header-cache and coinName can skip the RPC fetch entirely.
+func saveHeaderCache(path string, coinName string, headers []*wire.BlockHeader, height int64) error {
+	cached := cachedHeaderChain{
+		Coin:    coinName,
+		Height:  height,
+		Headers: make([]string, len(headers)),
+	}
+
+	for i, h := range headers {
+		var buf bytes.Buffer
+		if err := h.Serialize(&buf); err != nil {
+			return err
+		}
+		cached.Headers[i] = hex.EncodeToString(buf.Bytes())
+	}
+
+	data, err := json.MarshalIndent(&cached, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// BlockRecord is one simulated block's result, in a form suitable for every
+// --output sink.
+type BlockRecord struct {
+	Height            int64  `json:"height"`
+	Bits              uint32 `json:"bits"`
+	Target            string `json:"target"`
+	Work              string `json:"work"`
+	IntervalSeconds   int64  `json:"intervalSeconds"`
+	CumulativeSeconds int64  `json:"cumulativeSeconds"`
+	Hashrate          string `json:"hashrate"`
+	Algo              string `json:"algo"`
+}
+
+// Summary aggregates a completed simulation run.
+type Summary struct {
+	Blocks                 int64   `json:"blocks"`
+	MeanIntervalSeconds    float64 `json:"meanIntervalSeconds"`
+	MedianIntervalSeconds  float64 `json:"medianIntervalSeconds"`
+	StdDevIntervalSeconds  float64 `json:"stddevIntervalSeconds"`
+	PercentOutsideTarget   float64 `json:"percentOutsideTarget"`
+	LongestGapSeconds      int64   `json:"longestGapSeconds"`
+	ShortestGapSeconds     int64   `json:"shortestGapSeconds"`
+	RetargetResponseBlocks int     `json:"retargetResponseBlocks"`
+}
+
+// summarize computes a Summary over recs, given the network's intended
+// target spacing in seconds.
+func summarize(recs []BlockRecord, targetSpacingSeconds int64) Summary {
+	sum := Summary{Blocks: int64(len(recs))}
+	if len(recs) == 0 {
+		return sum
+	}
+
+	intervals := make([]float64, len(recs))
+	sorted := make([]int64, len(recs))
+	var total float64
+	outside := 0
+	longest := recs[0].IntervalSeconds
+	shortest := recs[0].IntervalSeconds
+	worstDeviationIdx := 0
+	worstDeviation := 0.0
+
+	for i, rec := range recs {
+		intervals[i] = float64(rec.IntervalSeconds)
+		sorted[i] = rec.IntervalSeconds
+		total += intervals[i]
+
+		if rec.IntervalSeconds > longest {
+			longest = rec.IntervalSeconds
+		}
+		if rec.IntervalSeconds < shortest {
+			shortest = rec.IntervalSeconds
+		}
+
+		deviation := math.Abs(float64(rec.IntervalSeconds)-float64(targetSpacingSeconds)) / float64(targetSpacingSeconds)
+		if deviation > 0.2 {
+			outside++
+		}
+		if deviation > worstDeviation {
+			worstDeviation = deviation
+			worstDeviationIdx = i
+		}
+	}
+
+	mean := total / float64(len(recs))
+
+	var variance float64
+	for _, v := range intervals {
+		variance += (v - mean) * (v - mean)
+	}
+	variance /= float64(len(recs))
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	median := float64(sorted[len(sorted)/2])
+	if len(sorted)%2 == 0 {
+		median = float64(sorted[len(sorted)/2-1]+sorted[len(sorted)/2]) / 2
+	}
+
+	responseBlocks := 0
+	for i := worstDeviationIdx + 1; i < len(recs); i++ {
+		responseBlocks++
+		deviation := math.Abs(float64(recs[i].IntervalSeconds)-float64(targetSpacingSeconds)) / float64(targetSpacingSeconds)
+		if deviation <= 0.2 {
+			break
+		}
+	}
+
+	sum.MeanIntervalSeconds = mean
+	sum.MedianIntervalSeconds = median
+	sum.StdDevIntervalSeconds = math.Sqrt(variance)
+	sum.PercentOutsideTarget = 100 * float64(outside) / float64(len(recs))
+	sum.LongestGapSeconds = longest
+	sum.ShortestGapSeconds = shortest
+	sum.RetargetResponseBlocks = responseBlocks
+
+	return sum
+}
+
+// ResultSink consumes block records as a simulation runs and finally the
+// run's Summary, in whichever format --output selected.
+type ResultSink interface {
+	WriteBlock(rec BlockRecord) error
+	WriteSummary(sum Summary) error
+	Close() error
+}
+
+// NewResultSink returns the ResultSink for the named --output format,
+// writing to w.
+func NewResultSink(format string, w io.Writer) (ResultSink, error) {
+	switch format {
+	case "text":
+		return &textSink{w: w}, nil
+	case "csv":
+		return &csvSink{w: csv.NewWriter(w)}, nil
+	case "json":
+		return &jsonSink{w: w}, nil
+	case "jsonl":
+		return &jsonlSink{enc: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// textSink writes the simulator's human-readable table: the original
+// height, diff bits and time columns plus a hashrate column, followed by a
+// one-line summary.
+type textSink struct {
+	w        io.Writer
+	wroteHdr bool
+}
+
+func (s *textSink) WriteBlock(rec BlockRecord) error {
+	if !s.wroteHdr {
+		fmt.Fprintf(s.w, "|%20s|%20s|%20s|%20s|\n", "Block Height", "Diff Bits", "Time to block", "Hashrate")
+		fmt.Fprintf(s.w, "|--------------------|--------------------|--------------------|--------------------|\n")
+		s.wroteHdr = true
+	}
+	seconds := rec.IntervalSeconds % 60
+	minutes := (rec.IntervalSeconds - seconds) / 60
+	_, err := fmt.Fprintf(s.w, "|%20d|%20x|%20s|%20s|\n",
+		rec.Height, rec.Bits, fmt.Sprintf("%dm%02ds", minutes, seconds), rec.Hashrate)
+	return err
+}
+
+func (s *textSink) WriteSummary(sum Summary) error {
+	_, err := fmt.Fprintf(s.w,
+		"Totally mined %d blocks, mean %.1fs / median %.1fs / stddev %.1fs per block, "+
+			"%.1f%% outside +/-20%% of target, longest gap %ds, shortest gap %ds, retarget responded in %d blocks\n",
+		sum.Blocks, sum.MeanIntervalSeconds, sum.MedianIntervalSeconds, sum.StdDevIntervalSeconds,
+		sum.PercentOutsideTarget, sum.LongestGapSeconds, sum.ShortestGapSeconds, sum.RetargetResponseBlocks)
+	return err
+}
+
+func (s *textSink) Close() error { return nil }
+
+// csvSink writes one row per block, followed by a blank line, a summary
+// header row and a summary row.
+type csvSink struct {
+	w        *csv.Writer
+	wroteHdr bool
+}
+
+func (s *csvSink) WriteBlock(rec BlockRecord) error {
+	if !s.wroteHdr {
+		s.w.Write([]string{"height", "bits", "target", "work", "intervalSeconds", "cumulativeSeconds", "hashrate", "algo"})
+		s.wroteHdr = true
+	}
+	return s.w.Write([]string{
+		fmt.Sprintf("%d", rec.Height),
+		fmt.Sprintf("%08x", rec.Bits),
+		rec.Target,
+		rec.Work,
+		fmt.Sprintf("%d", rec.IntervalSeconds),
+		fmt.Sprintf("%d", rec.CumulativeSeconds),
+		rec.Hashrate,
+		rec.Algo,
+	})
+}
+
+func (s *csvSink) WriteSummary(sum Summary) error {
+	s.w.Write([]string{})
+	s.w.Write([]string{"blocks", "meanIntervalSeconds", "medianIntervalSeconds", "stddevIntervalSeconds",
+		"percentOutsideTarget", "longestGapSeconds", "shortestGapSeconds", "retargetResponseBlocks"})
+	return s.w.Write([]string{
+		fmt.Sprintf("%d", sum.Blocks),
+		fmt.Sprintf("%f", sum.MeanIntervalSeconds),
+		fmt.Sprintf("%f", sum.MedianIntervalSeconds),
+		fmt.Sprintf("%f", sum.StdDevIntervalSeconds),
+		fmt.Sprintf("%f", sum.PercentOutsideTarget),
+		fmt.Sprintf("%d", sum.LongestGapSeconds),
+		fmt.Sprintf("%d", sum.ShortestGapSeconds),
+		fmt.Sprintf("%d", sum.RetargetResponseBlocks),
+	})
+}
+
+func (s *csvSink) Close() error {
+	s.w.Flush()
+	return s.w.Error()
+}
+
+// jsonSink buffers every block and writes a single {blocks, summary} JSON
+// object when WriteSummary is called.
+type jsonSink struct {
+	w      io.Writer
+	blocks []BlockRecord
+}
+
+func (s *jsonSink) WriteBlock(rec BlockRecord) error {
+	s.blocks = append(s.blocks, rec)
+	return nil
+}
+
+func (s *jsonSink) WriteSummary(sum Summary) error {
+	return json.NewEncoder(s.w).Encode(struct {
+		Blocks  []BlockRecord `json:"blocks"`
+		Summary Summary       `json:"summary"`
+	}{s.blocks, sum})
+}
+
+func (s *jsonSink) Close() error { return nil }
+
+// jsonlSink writes one JSON object per block as it arrives, followed by a
+// final JSON object carrying the summary.
+type jsonlSink struct {
+	enc *json.Encoder
+}
+
+func (s *jsonlSink) WriteBlock(rec BlockRecord) error {
+	return s.enc.Encode(rec)
+}
+
+func (s *jsonlSink) WriteSummary(sum Summary) error {
+	return s.enc.Encode(struct {
+		Summary Summary `json:"summary"`
+	}{sum})
+}
+
+func (s *jsonlSink) Close() error { return nil }
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/gertjaap/kgwsimulator/coinparam"
+	"github.com/mit-dci/lit/wire"
+)
+
+// simServer holds the header chain fetched at startup, so repeated
+// /simulate requests can run against it without each re-fetching
+// numSeedHeaders headers over RPC.
+type simServer struct {
+	coin    *coinparam.Params
+	headers []*wire.BlockHeader
+	height  int64
+}
+
+// runServeCommand implements the "serve" subcommand: it fetches (or loads
+// from --header-cache) a coin's header chain once, then exposes it over
+// HTTP so front-ends can plot difficulty curves without re-running the RPC
+// fetch for every query.
+func runServeCommand(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listenAddr := fs.String("listen", ":8080", "address to listen on")
+	coinName := fs.String("coin", "vtc", "coin to simulate (vtc, mona, dgb, ltc, btc, btc-testnet)")
+	rpcUser := fs.String("rpcuser", "", "RPC user to fetch blocks from the coin's reference node")
+	rpcPass := fs.String("rpcpass", "", "RPC password to fetch blocks from the coin's reference node")
+	headerCache := fs.String("header-cache", "", "path to cache the fetched header chain on disk, skipping the RPC fetch on subsequent runs")
+	fs.Parse(args)
+
+	coin, err := coinparam.ByName(*coinName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	connCfg := &rpcclient.ConnConfig{
+		Host:         fmt.Sprintf("localhost:%s", coin.DefaultRPCPort),
+		User:         *rpcUser,
+		Pass:         *rpcPass,
+		HTTPPostMode: true, // Bitcoin core only supports HTTP POST mode
+		DisableTLS:   true, // Bitcoin core does not provide TLS by default
+	}
+	client, err := rpcclient.New(connCfg, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	headers, height, err := fetchHeaders(client, coin.Name, *headerCache)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	srv := &simServer{coin: coin, headers: headers, height: height}
+	http.HandleFunc("/simulate", srv.handleSimulate)
+	http.HandleFunc("/headers", srv.handleHeaders)
+
+	log.Printf("serving %s on %s (%d headers through height %d)", coin.Name, *listenAddr, len(headers), height)
+	log.Fatal(http.ListenAndServe(*listenAddr, nil))
+}
+
+// handleSimulate runs a simulation against the server's cached header chain
+// and returns the resulting block records and summary as JSON. Recognized
+// query parameters: algo, hashrate (H/s), blocks, profile (hashrate mode),
+// and amplitude, period, attackerRate, triggerBits and exitBits for the
+// oscillating and hopping profiles.
+func (s *simServer) handleSimulate(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	algoName := q.Get("algo")
+	if algoName == "" {
+		algoName = "kgw"
+	}
+	algo, err := algorithmByName(algoName)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hashRate, ok := big.NewInt(0).SetString(q.Get("hashrate"), 10)
+	if !ok {
+		http.Error(w, "hashrate query parameter must be an integer number of H/s", http.StatusBadRequest)
+		return
+	}
+
+	numBlocks, err := strconv.ParseInt(q.Get("blocks"), 10, 64)
+	if err != nil || numBlocks <= 0 {
+		http.Error(w, "blocks query parameter must be a positive integer", http.StatusBadRequest)
+		return
+	}
+
+	profileName := q.Get("profile")
+	if profileName == "" {
+		profileName = "constant"
+	}
+
+	amplitude, _ := strconv.ParseInt(q.Get("amplitude"), 10, 64)
+	period, err := strconv.Atoi(q.Get("period"))
+	if err != nil {
+		period = 2016
+	}
+	attackerRate, _ := strconv.ParseInt(q.Get("attackerRate"), 10, 64)
+
+	profile, err := buildHashrateProfile(
+		profileName, "", hashRate, amplitude, period, attackerRate,
+		q.Get("triggerBits"), q.Get("exitBits"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	startDiff, err := algo.Calc(s.headers, int32(s.height), s.coin)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	records, summary, err := runSimulate(s.headers, s.height, SimulateOptions{
+		Coin:      s.coin,
+		AlgoName:  algoName,
+		Algo:      algo,
+		Profile:   profile,
+		NumBlocks: numBlocks,
+		StartDiff: startDiff,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Blocks  []BlockRecord `json:"blocks"`
+		Summary Summary       `json:"summary"`
+	}{records, summary})
+}
+
+// handleHeaders returns the server's cached header chain as JSON, so a
+// front-end can inspect the raw data a /simulate run is seeded from.
+func (s *simServer) handleHeaders(w http.ResponseWriter, r *http.Request) {
+	type headerJSON struct {
+		Height    int64  `json:"height"`
+		Bits      uint32 `json:"bits"`
+		Timestamp int64  `json:"timestamp"`
+	}
+
+	startHeight := s.height - int64(len(s.headers)) + 1
+	out := make([]headerJSON, len(s.headers))
+	for i, h := range s.headers {
+		out[i] = headerJSON{
+			Height:    startHeight + int64(i),
+			Bits:      h.Bits,
+			Timestamp: h.Timestamp.Unix(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(out)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/gertjaap/kgwsimulator/coinparam"
+	"github.com/mit-dci/lit/btcutil/chaincfg/chainhash"
+	"github.com/mit-dci/lit/wire"
+)
+
+// SimulateOptions bundles the parameters needed to run one simulated mining
+// run, shared by the CLI's default mode and the serve command's /simulate
+// endpoint.
+type SimulateOptions struct {
+	Coin          *coinparam.Params
+	AlgoName      string
+	Algo          DifficultyAlgorithm
+	Profile       HashrateProfile
+	NumBlocks     int64
+	StartDiff     uint32
+	Seed          int64
+	JitterSeconds int
+}
+
+// runSimulate simulates opts.NumBlocks blocks on top of seedHeaders, starting
+// at startHeight, and returns one BlockRecord per simulated block plus a
+// Summary of the whole run.
+func runSimulate(
+	seedHeaders []*wire.BlockHeader, startHeight int64,
+	opts SimulateOptions) ([]BlockRecord, Summary, error) {
+
+	headers := make([]*wire.BlockHeader, len(seedHeaders))
+	copy(headers, seedHeaders)
+
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	nullHash, _ := chainhash.NewHashFromStr("0000000000000000000000000000000000000000000000000000000000000000")
+	bestHeader := headers[len(headers)-1]
+	diff := opts.StartDiff
+	height := startHeight
+	totalSeconds := int64(0)
+
+	capacity := opts.NumBlocks
+	if capacity < 0 {
+		capacity = 0
+	}
+	records := make([]BlockRecord, 0, capacity)
+	for i := int64(0); i < opts.NumBlocks; i++ {
+		activeHashRate := opts.Profile.HashrateAt(int32(height+1), diff)
+		workForBlock := coinparam.CalcWork(diff)
+		timeInSeconds, err := sampleBlockInterval(rng, workForBlock, activeHashRate)
+		if err != nil {
+			return nil, Summary{}, err
+		}
+
+		height++
+		totalSeconds += timeInSeconds
+
+		records = append(records, BlockRecord{
+			Height:            height,
+			Bits:              diff,
+			Target:            coinparam.CompactToBig(diff).String(),
+			Work:              workForBlock.String(),
+			IntervalSeconds:   timeInSeconds,
+			CumulativeSeconds: totalSeconds,
+			Hashrate:          activeHashRate.String(),
+			Algo:              opts.AlgoName,
+		})
+
+		newHeader := wire.NewBlockHeader(nullHash, nullHash, diff, 0)
+		newHeader.Timestamp = bestHeader.Timestamp.Add(time.Second * time.Duration(timeInSeconds))
+		newHeader.Timestamp = jitterTimestamp(rng, newHeader.Timestamp, headers, opts.JitterSeconds)
+		headers = append(headers[1:], newHeader)
+
+		bestHeader = headers[len(headers)-1]
+
+		diff, err = opts.Algo.Calc(headers, int32(height), opts.Coin)
+		if err != nil {
+			return nil, Summary{}, err
+		}
+	}
+
+	targetSpacing := int64(opts.Coin.TargetTimePerBlock.Seconds())
+	if targetSpacing <= 0 {
+		targetSpacing = 1
+	}
+
+	return records, summarize(records, targetSpacing), nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gertjaap/kgwsimulator/coinparam"
+	"github.com/mit-dci/lit/btcutil/chaincfg/chainhash"
+	"github.com/mit-dci/lit/wire"
+)
+
+// ErrSolverInterrupted is returned by Solver.Solve when its Quit channel is
+// closed before a valid nonce is found.
+var ErrSolverInterrupted = errors.New("solver interrupted before finding a valid nonce")
+
+// SolveResult reports what a Solver.Solve call measured, so the analytic
+// CalcWork/hashrate estimate can be cross-checked against real mining
+// throughput.
+type SolveResult struct {
+	Nonce      uint32
+	ExtraNonce uint32
+	Hashes     uint64
+	Elapsed    time.Duration
+}
+
+// Solver searches for a nonce that satisfies a block's target, analogous to
+// the solveBlock helpers found in btcd/Bytom, by partitioning the 32-bit
+// nonce space across a fixed number of parallel goroutines.
+type Solver struct {
+	Workers int
+
+	// Quit, when closed, aborts any in-progress Solve call.
+	Quit chan struct{}
+}
+
+// NewSolver returns a Solver that partitions the nonce space across workers
+// goroutines. A non-positive workers defaults to runtime.NumCPU().
+func NewSolver(workers int) *Solver {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	return &Solver{Workers: workers, Quit: make(chan struct{})}
+}
+
+// Solve repeatedly searches the 32-bit nonce space of header for one that
+// satisfies targetBits under p's PoWFunction. Coins that only need a plain
+// header hash (no height-dependent algorithm switch) can set p.ChainhashFunc
+// instead, which Solve adapts to the PoWFunction signature. If neither is
+// set, Solve falls back to SHA256d. When the nonce space is exhausted
+// without success, the header's timestamp is bumped as an extranonce and
+// the search resumes from nonce zero.
+func (s *Solver) Solve(
+	header *wire.BlockHeader, targetBits uint32, p *coinparam.Params,
+	height int32) (*SolveResult, error) {
+
+	target := coinparam.CompactToBig(targetBits)
+	powFunc := p.PoWFunction
+	if powFunc == nil && p.ChainhashFunc != nil {
+		chainhashFunc := p.ChainhashFunc
+		powFunc = func(b []byte, height int32) chainhash.Hash { return chainhashFunc(b) }
+	}
+	if powFunc == nil {
+		powFunc = sha256dPoW
+	}
+
+	start := time.Now()
+	var totalHashes uint64
+	var extraNonce uint32
+
+	for {
+		stopCh := make(chan struct{})
+		var stopOnce sync.Once
+		foundNonce := make(chan uint32, 1)
+		var wg sync.WaitGroup
+
+		span := ^uint32(0) / uint32(s.Workers)
+		for w := 0; w < s.Workers; w++ {
+			lo := uint32(w) * span
+			hi := lo + span - 1
+			if w == s.Workers-1 {
+				hi = ^uint32(0)
+			}
+
+			wg.Add(1)
+			go func(lo, hi uint32) {
+				defer wg.Done()
+
+				h := *header
+				for nonce := lo; ; nonce++ {
+					select {
+					case <-stopCh:
+						return
+					case <-s.Quit:
+						return
+					default:
+					}
+
+					h.Nonce = nonce
+					hash := powFunc(serializeHeader(&h), height)
+					atomic.AddUint64(&totalHashes, 1)
+
+					if hashLessOrEqual(hash, target) {
+						stopOnce.Do(func() { close(stopCh) })
+						select {
+						case foundNonce <- nonce:
+						default:
+						}
+						return
+					}
+
+					if nonce == hi {
+						return
+					}
+				}
+			}(lo, hi)
+		}
+		wg.Wait()
+
+		select {
+		case nonce := <-foundNonce:
+			return &SolveResult{
+				Nonce:      nonce,
+				ExtraNonce: extraNonce,
+				Hashes:     totalHashes,
+				Elapsed:    time.Since(start),
+			}, nil
+		default:
+		}
+
+		select {
+		case <-s.Quit:
+			return nil, ErrSolverInterrupted
+		default:
+		}
+
+		// Exhausted the nonce space without success; roll the extranonce
+		// by bumping the timestamp and start the search over.
+		extraNonce++
+		header.Timestamp = header.Timestamp.Add(time.Second)
+	}
+}
+
+// serializeHeader returns the wire-serialized form of h.
+func serializeHeader(h *wire.BlockHeader) []byte {
+	var buf bytes.Buffer
+	h.Serialize(&buf)
+	return buf.Bytes()
+}
+
+// sha256dPoW is the default PoWFunction used for coins that don't register
+// their own (e.g. ones using Scrypt, Lyra2REv2 or Verthash), computing the
+// standard Bitcoin-style double SHA256 block hash.
+func sha256dPoW(b []byte, height int32) chainhash.Hash {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return chainhash.Hash(second)
+}
+
+// hashToBig interprets hash as a little-endian uint256, matching how block
+// hashes are compared against a target.
+func hashToBig(hash chainhash.Hash) *big.Int {
+	var reversed [chainhash.HashSize]byte
+	for i, b := range hash {
+		reversed[chainhash.HashSize-1-i] = b
+	}
+	return new(big.Int).SetBytes(reversed[:])
+}
+
+func hashLessOrEqual(hash chainhash.Hash, target *big.Int) bool {
+	return hashToBig(hash).Cmp(target) <= 0
+}
+
+// runSolveMode mines numBlocks real headers against coin's registered (or
+// default) PoWFunction instead of estimating block times analytically. Each
+// mined block's measured wall-clock time and hash count are printed next to
+// the analytic work/hashRate estimate so the two can be cross-checked, and
+// KGW's behavior can be validated against mined rather than simulated
+// headers.
+func runSolveMode(
+	lastBlocks []*wire.BlockHeader, height int64, coin *coinparam.Params,
+	algo DifficultyAlgorithm, numBlocks int64, diff uint32, hashRate *big.Int,
+	workers int) {
+
+	solver := NewSolver(workers)
+	bestHeader := lastBlocks[len(lastBlocks)-1]
+	nullHash, _ := chainhash.NewHashFromStr("0000000000000000000000000000000000000000000000000000000000000000")
+
+	fmt.Printf("|%20s|%20s|%20s|%20s|%20s|\n",
+		"Block Height", "Diff Bits", "Measured Time", "Estimated Time", "Hashes")
+	fmt.Printf("|--------------------|--------------------|--------------------|--------------------|--------------------|\n")
+
+	for i := int64(0); i < numBlocks; i++ {
+		template := wire.NewBlockHeader(nullHash, nullHash, diff, 0)
+		if bestHeader.Timestamp.After(time.Now()) {
+			template.Timestamp = bestHeader.Timestamp.Add(time.Second)
+		} else {
+			template.Timestamp = time.Now()
+		}
+
+		result, err := solver.Solve(template, diff, coin, int32(height+1))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		estimatedSeconds := new(big.Int).Div(coinparam.CalcWork(diff), hashRate).Int64()
+
+		height++
+		fmt.Printf("|%20d|%20x|%20s|%20s|%20d|\n", height, diff,
+			result.Elapsed.Round(time.Millisecond).String(),
+			fmt.Sprintf("%ds", estimatedSeconds), result.Hashes)
+
+		template.Nonce = result.Nonce
+		lastBlocks = append(lastBlocks[1:], template)
+
+		bestHeader = lastBlocks[len(lastBlocks)-1]
+		diff, err = algo.Calc(lastBlocks, int32(height), coin)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}